@@ -0,0 +1,100 @@
+package main
+
+import (
+	"bytes"
+	"image"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/image/tiff"
+
+	// registers the WEBP decoder with image.Decode / image.DecodeConfig, the
+	// only supported format with no direct encode/decode call in this file.
+	_ "golang.org/x/image/webp"
+)
+
+// defaultOutputQuality is used when QUALITY is unset or invalid.
+const defaultOutputQuality = 85
+
+// outputFormatFromEnv resolves OUTPUT_FORMAT to a concrete encoder name. An
+// unset value, or the literal "auto", means "match the source format".
+func outputFormatFromEnv(detected string) (format string, explicit bool) {
+	requested := strings.ToLower(os.Getenv("OUTPUT_FORMAT"))
+	if requested == "" || requested == "auto" {
+		return strings.ToLower(detected), false
+	}
+	return requested, true
+}
+
+// encodeImage encodes img with the encoder matching format, honoring QUALITY
+// for lossy formats, and returns the encoded bytes, the content type to
+// advertise on upload, and the format actually used. actual only ever
+// differs from format for "webp", which this package cannot encode.
+func encodeImage(img image.Image, format string) (data []byte, contentType string, actual string, err error) {
+	buff := new(bytes.Buffer)
+
+	switch format {
+	case "jpeg", "jpg":
+		quality := envInt("QUALITY", defaultOutputQuality)
+		if err := jpeg.Encode(buff, img, &jpeg.Options{Quality: quality}); err != nil {
+			return nil, "", "", err
+		}
+		return buff.Bytes(), "image/jpeg", "jpeg", nil
+	case "gif":
+		if err := gif.Encode(buff, img, nil); err != nil {
+			return nil, "", "", err
+		}
+		return buff.Bytes(), "image/gif", "gif", nil
+	case "tiff":
+		if err := tiff.Encode(buff, img, nil); err != nil {
+			return nil, "", "", err
+		}
+		return buff.Bytes(), "image/tiff", "tiff", nil
+	case "webp":
+		// golang.org/x/image/webp only decodes; fall back to JPEG, the most
+		// broadly compatible lossy format, for webp output requests. actual
+		// reports "jpeg" rather than echoing format back, so callers notice
+		// the fallback and don't mislabel the output as webp.
+		data, contentType, _, err = encodeImage(img, "jpeg")
+		return data, contentType, "jpeg", err
+	default:
+		if err := png.Encode(buff, img); err != nil {
+			return nil, "", "", err
+		}
+		return buff.Bytes(), "image/png", "png", nil
+	}
+}
+
+// extensionFor returns the file extension (without a leading dot) that
+// matches format.
+func extensionFor(format string) string {
+	switch format {
+	case "jpeg", "jpg":
+		return "jpg"
+	case "gif":
+		return "gif"
+	case "tiff":
+		return "tiff"
+	case "webp":
+		return "jpg" // encodeImage falls back to JPEG for webp output
+	default:
+		return "png"
+	}
+}
+
+// rewriteKeySuffix swaps key's extension for ext when rewrite is true
+// (OUTPUT_FORMAT named a concrete format, or the actual encoder used ended
+// up differing from the source format regardless, e.g. the webp fallback).
+// When rewrite is false the key is returned unchanged, preserving the
+// source item's original extension.
+func rewriteKeySuffix(key, ext string, rewrite bool) string {
+	if !rewrite {
+		return key
+	}
+	base := strings.TrimSuffix(key, filepath.Ext(key))
+	return base + "." + ext
+}
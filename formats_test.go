@@ -0,0 +1,30 @@
+package main
+
+import (
+	"image"
+	"testing"
+)
+
+func TestEncodeImageWebpFallsBackToJpeg(t *testing.T) {
+	src := image.NewRGBA(image.Rect(0, 0, 4, 4))
+
+	_, contentType, actual, err := encodeImage(src, "webp")
+	if err != nil {
+		t.Fatalf("encodeImage returned error: %v", err)
+	}
+	if actual != "jpeg" {
+		t.Fatalf("expected actual format jpeg for a webp request, got %q", actual)
+	}
+	if contentType != "image/jpeg" {
+		t.Fatalf("expected content type image/jpeg, got %q", contentType)
+	}
+}
+
+func TestRewriteKeySuffix(t *testing.T) {
+	if got := rewriteKeySuffix("processed-photo.webp", "jpg", false); got != "processed-photo.webp" {
+		t.Fatalf("expected key left unchanged when rewrite is false, got %q", got)
+	}
+	if got := rewriteKeySuffix("processed-photo.webp", "jpg", true); got != "processed-photo.jpg" {
+		t.Fatalf("expected extension rewritten when rewrite is true, got %q", got)
+	}
+}
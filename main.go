@@ -2,15 +2,13 @@ package main
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"image"
-	"image/png"
+	"io"
 	"os"
+	"strings"
 
-	"github.com/aws/aws-sdk-go/aws"
-	"github.com/aws/aws-sdk-go/aws/session"
-	"github.com/aws/aws-sdk-go/service/s3"
-	"github.com/aws/aws-sdk-go/service/s3/s3manager"
 	"github.com/disintegration/imaging"
 
 	"github.com/aws/aws-lambda-go/events"
@@ -21,96 +19,140 @@ import (
 type Response struct {
 	Message string `json:"message"`
 	Ok      bool   `json:"ok"`
+	// Variants holds the per-size outcome when the thumbnail pipeline ran.
+	// It is left empty for the single-image modification path.
+	Variants []VariantResult `json:"variants,omitempty"`
 }
 
-// Handler is the main handler function for AWS lambda
-func Handler(event events.S3Event) (Response, error) {
+// handler bundles the dependencies Handle needs, so they can be swapped out
+// in tests without touching real cloud storage.
+type handler struct {
+	// src is always S3: the Lambda is only ever triggered by an S3 event.
+	src Storage
+	// dst is selected via STORAGE_PROVIDER, letting processed images be
+	// mirrored to non-AWS targets.
+	dst Storage
+}
+
+// newHandler wires up the storage backends Handle needs from the process
+// environment.
+func newHandler() (*handler, error) {
+	src, err := newS3Storage()
+	if err != nil {
+		return nil, err
+	}
+	dst, err := NewStorage(context.Background(), storageProviderFromEnv())
+	if err != nil {
+		return nil, err
+	}
+	return &handler{src: src, dst: dst}, nil
+}
+
+// Handle is the testable core of Handler: download the triggering object,
+// modify it, and upload the result through dst.
+func (h *handler) Handle(event events.S3Event) (Response, error) {
+	ctx := context.Background()
+
 	// srcBucket is the name of the bucket in which a event occurred
 	// the handler is triggered by a PNG object creation event in a S3 bucket
 	srcBucket := event.Records[0].S3.Bucket.Name
 	// itemName is the name of the item created by the event
 	itemName := event.Records[0].S3.Object.Key
 
-	// putBucketName is the target S3 bucket to which the function stores the result
+	// putBucketName is the target bucket to which the function stores the result
 	// putBucketName is given as an environment variable
 	putBucketName := os.Getenv("PUT_BUCKET_NAME")
-	// modificationType is the image modification type, according to which the function modifies the image
-	/*
-		** types of modification **
-		1. grayscale	: changes the image to grayscale
-		2. invert		: negates the colors of the image
-		3. horizontal	: flips the image horizontally
-		4. vertical		: flips the image vertically
-	*/
-	// this function implements only four of the functions provided by the imaging package
-	// for more information, visit https://godoc.org/github.com/disintegration/imaging
-	// modificationType is given as an environment variable
-	modificationType := os.Getenv("MODIFICATION_TYPE")
-
-	// create a new session for S3
-	sess := session.Must(session.NewSession())
-
-	// create a buffer for storing objects fetched from S3
-	buff := &aws.WriteAtBuffer{}
-	// create a downloader object for managing downloads from S3
-	downloader := s3manager.NewDownloader(sess)
+
 	// download the image "itemName" from bucket "srcBucket"
-	// is stored into buffer buff
-	_, err := downloader.Download(buff, &s3.GetObjectInput{
-		Bucket: aws.String(srcBucket),
-		Key:    aws.String(itemName),
-	})
+	reader, err := h.src.Get(ctx, srcBucket, itemName)
 	if err != nil {
 		return Response{
 			Message: fmt.Sprint("Failed! An Error Occurred."),
 			Ok:      false,
 		}, err
 	}
+	defer reader.Close()
 
-	// read the bytes of the buffer buff and stores it to data
-	data := bytes.NewReader(buff.Bytes())
-	// decode the data and transfers it into a image
-	img, _, _ := image.Decode(data)
-
-	// according to modificationType, modify the img
-	switch modificationType {
-	case "grayscale":
-		img = imaging.Grayscale(img)
-	case "invert":
-		img = imaging.Invert(img)
-	case "horizontal":
-		img = imaging.FlipH(img)
-	case "vertical":
-		img = imaging.FlipV(img)
-	default:
-		img = img
+	raw, err := io.ReadAll(reader)
+	if err != nil {
+		return Response{
+			Message: fmt.Sprint("Failed! An Error Occurred."),
+			Ok:      false,
+		}, err
 	}
 
-	// create a buffer for storing the image
-	newBuff := new(bytes.Buffer)
-	// endcode the image file into bytes
-	err = png.Encode(newBuff, img)
+	// detect the source format (PNG/JPEG/GIF/WEBP/TIFF) without fully
+	// decoding the image, so OUTPUT_FORMAT="auto" can preserve it later
+	_, detectedFormat, err := image.DecodeConfig(bytes.NewReader(raw))
 	if err != nil {
 		return Response{
 			Message: fmt.Sprint("Failed! An Error Occurred."),
 			Ok:      false,
 		}, err
 	}
-	// read the bytes of the buffer newBuff and store it into sendData
-	// sendData is the data that will be stored into S3 Bucket putBucketName
-	sendData := bytes.NewReader(newBuff.Bytes())
-
-	// create a uploader object for managing uploads to S3
-	uploader := s3manager.NewUploader(sess)
-	// upload the data "sendData" into bucket "putBucketName"
-	// the item is stored as "modificationtype-itemName"
-	// if modification is "grayscale" and image name is "image.png",
-	// the result is "grayscale-image.png"
-	_, err = uploader.Upload(&s3manager.UploadInput{
-		Bucket: aws.String(putBucketName),
-		Key:    aws.String(modificationType + "-" + itemName),
-		Body:   sendData,
-	})
+
+	// decode the data into an image, auto-rotating it according to its EXIF
+	// orientation tag before any transformation is applied
+	img, err := imaging.Decode(bytes.NewReader(raw), imaging.AutoOrientation(true))
+	if err != nil {
+		return Response{
+			Message: fmt.Sprint("Failed! An Error Occurred."),
+			Ok:      false,
+		}, err
+	}
+
+	// THUMBNAIL_SIZES switches the function into the multi-size thumbnail
+	// pipeline: instead of a single modified copy, it renders one JPEG per
+	// configured width, gzips each, and uploads them all concurrently.
+	if os.Getenv("THUMBNAIL_SIZES") != "" {
+		return runThumbnailPipeline(ctx, h.dst, img, putBucketName, itemName)
+	}
+
+	// look up the object's x-amz-meta-pipeline metadata, when the backend
+	// supports it, to let the pipeline be driven per-object
+	var metadata map[string]string
+	if mg, ok := h.src.(MetadataGetter); ok {
+		metadata, _ = mg.GetMetadata(ctx, srcBucket, itemName)
+	}
+
+	// resolve and run the transform pipeline: x-amz-meta-pipeline metadata,
+	// then a PIPELINE_PREFIXES rule matching itemName, then the PIPELINE env
+	// var default
+	spec := resolvePipelineSpec(metadata, itemName)
+	transforms, err := ParsePipeline(ctx, spec)
+	if err != nil {
+		return Response{
+			Message: fmt.Sprint("Failed! An Error Occurred."),
+			Ok:      false,
+		}, err
+	}
+	img, err = applyPipeline(img, transforms)
+	if err != nil {
+		return Response{
+			Message: fmt.Sprint("Failed! An Error Occurred."),
+			Ok:      false,
+		}, err
+	}
+
+	// resolve OUTPUT_FORMAT ("auto" by default, which matches detectedFormat)
+	// and encode the image file into bytes accordingly
+	format, explicitFormat := outputFormatFromEnv(detectedFormat)
+	encoded, contentType, actualFormat, err := encodeImage(img, format)
+	if err != nil {
+		return Response{
+			Message: fmt.Sprint("Failed! An Error Occurred."),
+			Ok:      false,
+		}, err
+	}
+
+	// upload the data into bucket "putBucketName" via dst
+	// the item is stored as "processed-itemName", e.g. "processed-photo.png"
+	// (or "processed-photo.jpg" when OUTPUT_FORMAT picks a different format
+	// than the source, or when the chosen encoder had to fall back to one,
+	// e.g. webp sources always encode out as jpeg)
+	rewriteKey := explicitFormat || !strings.EqualFold(actualFormat, detectedFormat)
+	key := rewriteKeySuffix("processed-"+itemName, extensionFor(actualFormat), rewriteKey)
+	err = h.dst.Put(ctx, putBucketName, key, bytes.NewReader(encoded), PutOptions{ContentType: contentType})
 	if err != nil {
 		return Response{
 			Message: fmt.Sprint("Failed! An Error Occurred."),
@@ -124,6 +166,18 @@ func Handler(event events.S3Event) (Response, error) {
 	}, nil
 }
 
+// Handler is the entrypoint AWS Lambda invokes for every S3 event.
+func Handler(event events.S3Event) (Response, error) {
+	h, err := newHandler()
+	if err != nil {
+		return Response{
+			Message: fmt.Sprint("Failed! An Error Occurred."),
+			Ok:      false,
+		}, err
+	}
+	return h.Handle(event)
+}
+
 func main() {
 	lambda.Start(Handler)
 }
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+func s3EventFor(bucket, key string) events.S3Event {
+	return events.S3Event{Records: []events.S3EventRecord{{
+		S3: events.S3Entity{
+			Bucket: events.S3Bucket{Name: bucket},
+			Object: events.S3Object{Key: key},
+		},
+	}}}
+}
+
+func TestHandleGrayscale(t *testing.T) {
+	t.Setenv("PUT_BUCKET_NAME", "dst-bucket")
+	t.Setenv("PIPELINE", "grayscale")
+
+	src := newFakeStorage()
+	dst := newFakeStorage()
+
+	img := image.NewRGBA(image.Rect(0, 0, 2, 2))
+	img.Set(0, 0, color.RGBA{255, 0, 0, 255})
+	buf := new(bytes.Buffer)
+	if err := png.Encode(buf, img); err != nil {
+		t.Fatalf("encoding fixture image: %v", err)
+	}
+	src.objects[fakeStorageKey("src-bucket", "photo.png")] = buf.Bytes()
+
+	h := &handler{src: src, dst: dst}
+
+	resp, err := h.Handle(s3EventFor("src-bucket", "photo.png"))
+	if err != nil {
+		t.Fatalf("Handle returned error: %v", err)
+	}
+	if !resp.Ok {
+		t.Fatalf("expected Ok response, got %+v", resp)
+	}
+
+	if _, ok := dst.objects[fakeStorageKey("dst-bucket", "processed-photo.png")]; !ok {
+		t.Fatalf("expected processed-photo.png to be uploaded to dst-bucket")
+	}
+	if got := dst.puts[fakeStorageKey("dst-bucket", "processed-photo.png")].ContentType; got != "image/png" {
+		t.Fatalf("expected ContentType image/png, got %q", got)
+	}
+}
+
+func TestHandleMissingSourceObject(t *testing.T) {
+	t.Setenv("PUT_BUCKET_NAME", "dst-bucket")
+	t.Setenv("PIPELINE", "invert")
+
+	h := &handler{src: newFakeStorage(), dst: newFakeStorage()}
+
+	resp, err := h.Handle(s3EventFor("src-bucket", "missing.png"))
+	if err == nil {
+		t.Fatalf("expected an error for a missing source object")
+	}
+	if resp.Ok {
+		t.Fatalf("expected Ok=false response, got %+v", resp)
+	}
+}
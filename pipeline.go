@@ -0,0 +1,267 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"image"
+	"image/color"
+	"strconv"
+	"strings"
+
+	"github.com/disintegration/imaging"
+)
+
+// Transform applies one pipeline step to img and returns the result.
+type Transform func(img image.Image) (image.Image, error)
+
+// TransformFactory builds a Transform from the raw argument string that
+// follows a step's ':' in a pipeline spec (e.g. "800x600" for
+// "resize:800x600", "" for "grayscale"). It receives ctx because some steps
+// (watermark) need to fetch an asset while the pipeline is being built.
+type TransformFactory func(ctx context.Context, arg string) (Transform, error)
+
+// transformRegistry maps a pipeline step name to the factory that builds it.
+// New operations are added here without touching Handler.
+var transformRegistry = map[string]TransformFactory{
+	"resize":            newResizeTransform,
+	"thumbnail":         newThumbnailTransform,
+	"crop":              newCropTransform,
+	"rotate":            newRotateTransform,
+	"blur":              newBlurTransform,
+	"sharpen":           newSharpenTransform,
+	"adjust-contrast":   newContrastTransform,
+	"adjust-brightness": newBrightnessTransform,
+	"grayscale":         newGrayscaleTransform,
+	"invert":            newInvertTransform,
+	"flip-h":            newFlipHTransform,
+	"flip-v":            newFlipVTransform,
+	"watermark":         newWatermarkTransform,
+	"overlay":           newWatermarkTransform,
+}
+
+// ParsePipeline parses a pipeline spec such as
+// "resize:800x600|grayscale|blur:2|watermark:s3://bucket/logo.png@br" into an
+// ordered slice of Transforms, looking up each step's name in
+// transformRegistry.
+func ParsePipeline(ctx context.Context, spec string) ([]Transform, error) {
+	transforms := make([]Transform, 0)
+	for _, step := range strings.Split(spec, "|") {
+		step = strings.TrimSpace(step)
+		if step == "" {
+			continue
+		}
+
+		name, arg, _ := strings.Cut(step, ":")
+		factory, ok := transformRegistry[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown pipeline step %q", name)
+		}
+
+		transform, err := factory(ctx, arg)
+		if err != nil {
+			return nil, fmt.Errorf("pipeline step %q: %w", step, err)
+		}
+		transforms = append(transforms, transform)
+	}
+	return transforms, nil
+}
+
+// applyPipeline runs img through transforms in order.
+func applyPipeline(img image.Image, transforms []Transform) (image.Image, error) {
+	for _, transform := range transforms {
+		var err error
+		img, err = transform(img)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return img, nil
+}
+
+// parseDimensions parses a "WIDTHxHEIGHT" argument.
+func parseDimensions(arg string) (width, height int, err error) {
+	w, h, ok := strings.Cut(arg, "x")
+	if !ok {
+		return 0, 0, fmt.Errorf("expected WIDTHxHEIGHT, got %q", arg)
+	}
+	width, err = strconv.Atoi(strings.TrimSpace(w))
+	if err != nil {
+		return 0, 0, err
+	}
+	height, err = strconv.Atoi(strings.TrimSpace(h))
+	if err != nil {
+		return 0, 0, err
+	}
+	return width, height, nil
+}
+
+func newResizeTransform(ctx context.Context, arg string) (Transform, error) {
+	width, height, err := parseDimensions(arg)
+	if err != nil {
+		return nil, err
+	}
+	return func(img image.Image) (image.Image, error) {
+		return imaging.Resize(img, width, height, imaging.Lanczos), nil
+	}, nil
+}
+
+func newThumbnailTransform(ctx context.Context, arg string) (Transform, error) {
+	width, height, err := parseDimensions(arg)
+	if err != nil {
+		return nil, err
+	}
+	return func(img image.Image) (image.Image, error) {
+		return imaging.Thumbnail(img, width, height, imaging.Lanczos), nil
+	}, nil
+}
+
+func newCropTransform(ctx context.Context, arg string) (Transform, error) {
+	width, height, err := parseDimensions(arg)
+	if err != nil {
+		return nil, err
+	}
+	return func(img image.Image) (image.Image, error) {
+		return imaging.CropCenter(img, width, height), nil
+	}, nil
+}
+
+func newRotateTransform(ctx context.Context, arg string) (Transform, error) {
+	angle, err := strconv.ParseFloat(arg, 64)
+	if err != nil {
+		return nil, err
+	}
+	return func(img image.Image) (image.Image, error) {
+		return imaging.Rotate(img, angle, color.Transparent), nil
+	}, nil
+}
+
+func newBlurTransform(ctx context.Context, arg string) (Transform, error) {
+	sigma, err := strconv.ParseFloat(arg, 64)
+	if err != nil {
+		return nil, err
+	}
+	return func(img image.Image) (image.Image, error) {
+		return imaging.Blur(img, sigma), nil
+	}, nil
+}
+
+func newSharpenTransform(ctx context.Context, arg string) (Transform, error) {
+	sigma, err := strconv.ParseFloat(arg, 64)
+	if err != nil {
+		return nil, err
+	}
+	return func(img image.Image) (image.Image, error) {
+		return imaging.Sharpen(img, sigma), nil
+	}, nil
+}
+
+func newContrastTransform(ctx context.Context, arg string) (Transform, error) {
+	percentage, err := strconv.ParseFloat(arg, 64)
+	if err != nil {
+		return nil, err
+	}
+	return func(img image.Image) (image.Image, error) {
+		return imaging.AdjustContrast(img, percentage), nil
+	}, nil
+}
+
+func newBrightnessTransform(ctx context.Context, arg string) (Transform, error) {
+	percentage, err := strconv.ParseFloat(arg, 64)
+	if err != nil {
+		return nil, err
+	}
+	return func(img image.Image) (image.Image, error) {
+		return imaging.AdjustBrightness(img, percentage), nil
+	}, nil
+}
+
+func newGrayscaleTransform(ctx context.Context, arg string) (Transform, error) {
+	return func(img image.Image) (image.Image, error) {
+		return imaging.Grayscale(img), nil
+	}, nil
+}
+
+func newInvertTransform(ctx context.Context, arg string) (Transform, error) {
+	return func(img image.Image) (image.Image, error) {
+		return imaging.Invert(img), nil
+	}, nil
+}
+
+func newFlipHTransform(ctx context.Context, arg string) (Transform, error) {
+	return func(img image.Image) (image.Image, error) {
+		return imaging.FlipH(img), nil
+	}, nil
+}
+
+func newFlipVTransform(ctx context.Context, arg string) (Transform, error) {
+	return func(img image.Image) (image.Image, error) {
+		return imaging.FlipV(img), nil
+	}, nil
+}
+
+// newWatermarkTransform builds an overlay/watermark step from an argument of
+// the form "s3://bucket/logo.png@br", where the "@anchor" suffix is one of
+// tl, tr, bl, br or center and defaults to br. The watermark image is
+// fetched once, while the pipeline is built, and reused for every step
+// invocation.
+func newWatermarkTransform(ctx context.Context, arg string) (Transform, error) {
+	uri, anchor, _ := strings.Cut(arg, "@")
+	if anchor == "" {
+		anchor = "br"
+	}
+
+	bucket, key, err := parseS3URI(uri)
+	if err != nil {
+		return nil, err
+	}
+
+	storage, err := newS3Storage()
+	if err != nil {
+		return nil, err
+	}
+	reader, err := storage.Get(ctx, bucket, key)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	overlay, err := imaging.Decode(reader, imaging.AutoOrientation(true))
+	if err != nil {
+		return nil, err
+	}
+
+	return func(img image.Image) (image.Image, error) {
+		offset := anchorOffset(anchor, img.Bounds().Size(), overlay.Bounds().Size())
+		return imaging.Overlay(img, overlay, offset, 1.0), nil
+	}, nil
+}
+
+// parseS3URI splits an "s3://bucket/key" URI into its bucket and key parts.
+func parseS3URI(uri string) (bucket, key string, err error) {
+	const prefix = "s3://"
+	if !strings.HasPrefix(uri, prefix) {
+		return "", "", fmt.Errorf("expected an s3:// URI, got %q", uri)
+	}
+	bucket, key, ok := strings.Cut(strings.TrimPrefix(uri, prefix), "/")
+	if !ok || key == "" {
+		return "", "", fmt.Errorf("expected s3://bucket/key, got %q", uri)
+	}
+	return bucket, key, nil
+}
+
+// anchorOffset computes the top-left placement of a fgSize-sized overlay
+// within a bgSize-sized background for the given anchor.
+func anchorOffset(anchor string, bgSize, fgSize image.Point) image.Point {
+	switch anchor {
+	case "tl":
+		return image.Pt(0, 0)
+	case "tr":
+		return image.Pt(bgSize.X-fgSize.X, 0)
+	case "bl":
+		return image.Pt(0, bgSize.Y-fgSize.Y)
+	case "center":
+		return image.Pt((bgSize.X-fgSize.X)/2, (bgSize.Y-fgSize.Y)/2)
+	default: // "br"
+		return image.Pt(bgSize.X-fgSize.X, bgSize.Y-fgSize.Y)
+	}
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"context"
+	"os"
+	"strings"
+)
+
+// MetadataGetter is implemented by Storage backends that can report
+// user-defined object metadata (e.g. S3's x-amz-meta-* headers).
+type MetadataGetter interface {
+	GetMetadata(ctx context.Context, bucket, key string) (map[string]string, error)
+}
+
+// resolvePipelineSpec picks the pipeline spec to run for itemName, in
+// priority order: the source object's x-amz-meta-pipeline metadata, a
+// PIPELINE_PREFIXES rule matching the object key, and finally the PIPELINE
+// env var default.
+func resolvePipelineSpec(metadata map[string]string, itemName string) string {
+	if spec := metadata["pipeline"]; spec != "" {
+		return spec
+	}
+	if spec := pipelineForPrefix(itemName, os.Getenv("PIPELINE_PREFIXES")); spec != "" {
+		return spec
+	}
+	return os.Getenv("PIPELINE")
+}
+
+// pipelineForPrefix parses a PIPELINE_PREFIXES value of the form
+// "prefix=spec;prefix2=spec2" and returns the spec for the longest prefix
+// matching key, or "" when none match.
+func pipelineForPrefix(key, rules string) string {
+	best, bestLen := "", -1
+	for _, rule := range strings.Split(rules, ";") {
+		rule = strings.TrimSpace(rule)
+		if rule == "" {
+			continue
+		}
+		prefix, spec, ok := strings.Cut(rule, "=")
+		if !ok {
+			continue
+		}
+		if strings.HasPrefix(key, prefix) && len(prefix) > bestLen {
+			best, bestLen = spec, len(prefix)
+		}
+	}
+	return best
+}
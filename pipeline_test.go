@@ -0,0 +1,48 @@
+package main
+
+import (
+	"context"
+	"image"
+	"testing"
+)
+
+func TestParsePipelineAppliesStepsInOrder(t *testing.T) {
+	transforms, err := ParsePipeline(context.Background(), "resize:4x4|grayscale")
+	if err != nil {
+		t.Fatalf("ParsePipeline returned error: %v", err)
+	}
+	if len(transforms) != 2 {
+		t.Fatalf("expected 2 transforms, got %d", len(transforms))
+	}
+
+	src := image.NewRGBA(image.Rect(0, 0, 8, 8))
+	out, err := applyPipeline(src, transforms)
+	if err != nil {
+		t.Fatalf("applyPipeline returned error: %v", err)
+	}
+	if got := out.Bounds().Dx(); got != 4 {
+		t.Fatalf("expected resized width 4, got %d", got)
+	}
+}
+
+func TestParsePipelineUnknownStep(t *testing.T) {
+	if _, err := ParsePipeline(context.Background(), "sepia"); err == nil {
+		t.Fatalf("expected an error for an unknown pipeline step")
+	}
+}
+
+func TestPipelineForPrefixPrefersLongestMatch(t *testing.T) {
+	rules := "thumbs/=resize:100x100;thumbs/avatars/=resize:64x64"
+	got := pipelineForPrefix("thumbs/avatars/me.png", rules)
+	if got != "resize:64x64" {
+		t.Fatalf("expected the longest matching prefix's spec, got %q", got)
+	}
+}
+
+func TestResolvePipelineSpecPrefersMetadata(t *testing.T) {
+	t.Setenv("PIPELINE", "grayscale")
+	metadata := map[string]string{"pipeline": "invert"}
+	if got := resolvePipelineSpec(metadata, "photo.png"); got != "invert" {
+		t.Fatalf("expected metadata pipeline to win, got %q", got)
+	}
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+)
+
+// PutOptions carries the per-object upload parameters that make sense across
+// every storage provider.
+type PutOptions struct {
+	// ContentType is the MIME type of body, e.g. "image/png".
+	ContentType string
+	// ContentEncoding is set when body is compressed, e.g. "gzip".
+	ContentEncoding string
+}
+
+// Storage abstracts the object store Handler downloads from and uploads to,
+// so the same image-processing logic can target AWS S3, Google Cloud
+// Storage, Google Drive or the local filesystem.
+type Storage interface {
+	// Get fetches the object named key from bucket and returns a reader for
+	// its contents. Callers must close the returned reader.
+	Get(ctx context.Context, bucket, key string) (io.ReadCloser, error)
+	// Put uploads body to bucket under key, applying opts.
+	Put(ctx context.Context, bucket, key string, body io.Reader, opts PutOptions) error
+}
+
+// NewStorage builds the Storage backend named by provider ("s3", "gcs",
+// "gdrive" or "local"). An empty provider defaults to "s3", matching the
+// function's original AWS-only behavior.
+func NewStorage(ctx context.Context, provider string) (Storage, error) {
+	switch provider {
+	case "", "s3":
+		return newS3Storage()
+	case "gcs":
+		return newGCSStorage(ctx)
+	case "gdrive":
+		return newGDriveStorage(ctx)
+	case "local":
+		return newLocalStorage()
+	default:
+		return nil, fmt.Errorf("unknown STORAGE_PROVIDER %q", provider)
+	}
+}
+
+// storageProviderFromEnv reads STORAGE_PROVIDER, defaulting to "s3".
+func storageProviderFromEnv() string {
+	if provider := os.Getenv("STORAGE_PROVIDER"); provider != "" {
+		return provider
+	}
+	return "s3"
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// fakeStorage is an in-memory Storage used to exercise Handle without
+// talking to any real cloud provider. mu guards objects/puts since the
+// thumbnail pipeline uploads variants concurrently.
+type fakeStorage struct {
+	mu      sync.Mutex
+	objects map[string][]byte
+	puts    map[string]PutOptions
+}
+
+func newFakeStorage() *fakeStorage {
+	return &fakeStorage{
+		objects: make(map[string][]byte),
+		puts:    make(map[string]PutOptions),
+	}
+}
+
+func fakeStorageKey(bucket, key string) string {
+	return bucket + "/" + key
+}
+
+func (f *fakeStorage) Get(ctx context.Context, bucket, key string) (io.ReadCloser, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	data, ok := f.objects[fakeStorageKey(bucket, key)]
+	if !ok {
+		return nil, fmt.Errorf("fakeStorage: object %s/%s not found", bucket, key)
+	}
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
+
+func (f *fakeStorage) Put(ctx context.Context, bucket, key string, body io.Reader, opts PutOptions) error {
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.objects[fakeStorageKey(bucket, key)] = data
+	f.puts[fakeStorageKey(bucket, key)] = opts
+	return nil
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"context"
+	"io"
+	"os"
+
+	"cloud.google.com/go/storage"
+	"google.golang.org/api/option"
+)
+
+// gcsStorage is the Storage implementation backed by Google Cloud Storage.
+type gcsStorage struct {
+	client *storage.Client
+}
+
+// newGCSStorage builds a gcsStorage client. GCS_CREDENTIALS_FILE may point at
+// a service-account JSON key; when unset, the client falls back to
+// application default credentials.
+func newGCSStorage(ctx context.Context) (*gcsStorage, error) {
+	var opts []option.ClientOption
+	if credsFile := os.Getenv("GCS_CREDENTIALS_FILE"); credsFile != "" {
+		opts = append(opts, option.WithCredentialsFile(credsFile))
+	}
+	client, err := storage.NewClient(ctx, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &gcsStorage{client: client}, nil
+}
+
+// Get fetches bucket/key from GCS and returns its contents as a reader.
+func (g *gcsStorage) Get(ctx context.Context, bucket, key string) (io.ReadCloser, error) {
+	return g.client.Bucket(bucket).Object(key).NewReader(ctx)
+}
+
+// Put uploads body to bucket/key, applying opts.
+func (g *gcsStorage) Put(ctx context.Context, bucket, key string, body io.Reader, opts PutOptions) error {
+	w := g.client.Bucket(bucket).Object(key).NewWriter(ctx)
+	if opts.ContentType != "" {
+		w.ContentType = opts.ContentType
+	}
+	if opts.ContentEncoding != "" {
+		w.ContentEncoding = opts.ContentEncoding
+	}
+	if _, err := io.Copy(w, body); err != nil {
+		w.Close()
+		return err
+	}
+	return w.Close()
+}
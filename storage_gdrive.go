@@ -0,0 +1,89 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"google.golang.org/api/drive/v3"
+	"google.golang.org/api/option"
+)
+
+// gdriveStorage is the Storage implementation backed by Google Drive. bucket
+// is treated as the id of the parent Drive folder and key as the file name
+// within it.
+type gdriveStorage struct {
+	service *drive.Service
+}
+
+// newGDriveStorage builds a gdriveStorage client from the service-account key
+// named by GDRIVE_CREDENTIALS_FILE.
+func newGDriveStorage(ctx context.Context) (*gdriveStorage, error) {
+	credsFile := os.Getenv("GDRIVE_CREDENTIALS_FILE")
+	if credsFile == "" {
+		return nil, fmt.Errorf("GDRIVE_CREDENTIALS_FILE must be set for STORAGE_PROVIDER=gdrive")
+	}
+	service, err := drive.NewService(ctx, option.WithCredentialsFile(credsFile))
+	if err != nil {
+		return nil, err
+	}
+	return &gdriveStorage{service: service}, nil
+}
+
+// Get downloads the file named key from the Drive folder bucket.
+func (g *gdriveStorage) Get(ctx context.Context, bucket, key string) (io.ReadCloser, error) {
+	file, err := g.findFile(bucket, key)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := g.service.Files.Get(file.Id).Context(ctx).Download()
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+// Put uploads body as a file named key inside the Drive folder bucket,
+// overwriting any existing file with the same name.
+func (g *gdriveStorage) Put(ctx context.Context, bucket, key string, body io.Reader, opts PutOptions) error {
+	existing, err := g.findFile(bucket, key)
+	if err == nil && existing != nil {
+		_, err := g.service.Files.Update(existing.Id, &drive.File{}).Media(body).Context(ctx).Do()
+		return err
+	}
+
+	file := &drive.File{
+		Name:    key,
+		Parents: []string{bucket},
+	}
+	if opts.ContentType != "" {
+		file.MimeType = opts.ContentType
+	}
+	_, err = g.service.Files.Create(file).Media(body).Context(ctx).Do()
+	return err
+}
+
+// findFile looks up the file named key within Drive folder parent.
+func (g *gdriveStorage) findFile(parent, name string) (*drive.File, error) {
+	query := fmt.Sprintf("'%s' in parents and name = '%s' and trashed = false", escapeDriveQueryValue(parent), escapeDriveQueryValue(name))
+	result, err := g.service.Files.List().Q(query).PageSize(1).Do()
+	if err != nil {
+		return nil, err
+	}
+	if len(result.Files) == 0 {
+		return nil, fmt.Errorf("gdrive: file %q not found in folder %q", name, parent)
+	}
+	return result.Files[0], nil
+}
+
+// escapeDriveQueryValue escapes backslashes and single quotes in a value
+// interpolated into a Drive API query string, per Drive's query syntax,
+// so a bucket or object key containing a quote can't break out of its
+// quoted literal and alter the query.
+func escapeDriveQueryValue(value string) string {
+	value = strings.ReplaceAll(value, `\`, `\\`)
+	value = strings.ReplaceAll(value, `'`, `\'`)
+	return value
+}
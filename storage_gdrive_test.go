@@ -0,0 +1,20 @@
+package main
+
+import "testing"
+
+func TestEscapeDriveQueryValue(t *testing.T) {
+	cases := []struct {
+		in   string
+		want string
+	}{
+		{"photo.png", "photo.png"},
+		{"it's/a'key", `it\'s/a\'key`},
+		{`back\slash`, `back\\slash`},
+	}
+
+	for _, c := range cases {
+		if got := escapeDriveQueryValue(c.in); got != c.want {
+			t.Fatalf("escapeDriveQueryValue(%q) = %q, want %q", c.in, got, c.want)
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// localStorage is the Storage implementation backed by the local filesystem,
+// mainly useful for development and testing without any cloud credentials.
+// bucket is treated as a directory relative to LOCAL_STORAGE_ROOT and key as
+// the file name within it.
+type localStorage struct {
+	root string
+}
+
+// newLocalStorage builds a localStorage rooted at LOCAL_STORAGE_ROOT,
+// defaulting to the current working directory when unset.
+func newLocalStorage() (*localStorage, error) {
+	root := os.Getenv("LOCAL_STORAGE_ROOT")
+	if root == "" {
+		root = "."
+	}
+	return &localStorage{root: root}, nil
+}
+
+// Get opens bucket/key as a file under the storage root.
+func (l *localStorage) Get(ctx context.Context, bucket, key string) (io.ReadCloser, error) {
+	path, err := l.resolve(bucket, key)
+	if err != nil {
+		return nil, err
+	}
+	return os.Open(path)
+}
+
+// Put writes body to bucket/key under the storage root, creating the bucket
+// directory if needed. opts is ignored: plain files have no content-type or
+// content-encoding metadata to set.
+func (l *localStorage) Put(ctx context.Context, bucket, key string, body io.Reader, opts PutOptions) error {
+	path, err := l.resolve(bucket, key)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = io.Copy(f, body)
+	return err
+}
+
+// resolve joins bucket/key onto the storage root and verifies the result
+// stays under it, rejecting the path traversal that a crafted S3 object key
+// (e.g. "../../etc/cron.d/evil") would otherwise allow.
+func (l *localStorage) resolve(bucket, key string) (string, error) {
+	root, err := filepath.Abs(l.root)
+	if err != nil {
+		return "", err
+	}
+
+	path, err := filepath.Abs(filepath.Join(root, bucket, key))
+	if err != nil {
+		return "", err
+	}
+
+	if path != root && !strings.HasPrefix(path, root+string(os.PathSeparator)) {
+		return "", fmt.Errorf("local storage: %q escapes storage root %q", filepath.Join(bucket, key), root)
+	}
+	return path, nil
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestLocalStorageRejectsPathTraversal(t *testing.T) {
+	storage := &localStorage{root: t.TempDir()}
+
+	_, err := storage.Get(context.Background(), "dst-bucket", "a/../../../etc/cron.d/evil")
+	if err == nil {
+		t.Fatalf("expected Get to reject a key that escapes the storage root")
+	}
+
+	err = storage.Put(context.Background(), "dst-bucket", "a/../../../etc/cron.d/evil", strings.NewReader("evil"), PutOptions{})
+	if err == nil {
+		t.Fatalf("expected Put to reject a key that escapes the storage root")
+	}
+}
+
+func TestLocalStorageRoundTrip(t *testing.T) {
+	storage := &localStorage{root: t.TempDir()}
+	ctx := context.Background()
+
+	if err := storage.Put(ctx, "dst-bucket", "processed-photo.png", strings.NewReader("fake-png-bytes"), PutOptions{}); err != nil {
+		t.Fatalf("Put returned error: %v", err)
+	}
+
+	reader, err := storage.Get(ctx, "dst-bucket", "processed-photo.png")
+	if err != nil {
+		t.Fatalf("Get returned error: %v", err)
+	}
+	defer reader.Close()
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3manager"
+)
+
+// s3Storage is the Storage implementation backed by AWS S3. It is the
+// function's original, and default, provider.
+type s3Storage struct {
+	client           *s3.S3
+	downloader       *s3manager.Downloader
+	uploader         *s3manager.Uploader
+	extras           s3UploadExtras
+	disableMultipart bool
+}
+
+// newS3Storage builds an s3Storage from a fresh AWS session. Credentials and
+// region are resolved the usual AWS SDK way (environment, shared config, or
+// the Lambda execution role); S3_ENDPOINT and S3_FORCE_PATH_STYLE redirect
+// it at an S3-compatible store instead.
+func newS3Storage() (*s3Storage, error) {
+	sess, err := session.NewSession(s3SessionConfig())
+	if err != nil {
+		return nil, err
+	}
+	extras, err := s3UploadExtrasFromEnv()
+	if err != nil {
+		return nil, err
+	}
+	client := s3.New(sess)
+	return &s3Storage{
+		client:           client,
+		downloader:       s3manager.NewDownloaderWithClient(client),
+		uploader:         s3manager.NewUploaderWithClient(client, configureUploader),
+		extras:           extras,
+		disableMultipart: envBool("S3_DISABLE_MULTIPART", false),
+	}, nil
+}
+
+// Get downloads bucket/key from S3 and returns its contents as a reader.
+func (s *s3Storage) Get(ctx context.Context, bucket, key string) (io.ReadCloser, error) {
+	buff := &aws.WriteAtBuffer{}
+	_, err := s.downloader.DownloadWithContext(ctx, buff, &s3.GetObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return io.NopCloser(bytes.NewReader(buff.Bytes())), nil
+}
+
+// Put uploads body to bucket/key, applying the destination storage class and
+// server-side encryption settings configured via S3_STORAGE_CLASS, S3_SSE
+// and S3_SSE_KMS_KEY_ID. It goes through the S3 transfer manager (tuned by
+// S3_PART_SIZE and S3_CONCURRENCY) unless S3_DISABLE_MULTIPART is set, in
+// which case it falls back to a single s3.PutObject call.
+func (s *s3Storage) Put(ctx context.Context, bucket, key string, body io.Reader, opts PutOptions) error {
+	if s.disableMultipart {
+		return s.putObject(ctx, bucket, key, body, opts)
+	}
+
+	input := &s3manager.UploadInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+		Body:   body,
+	}
+	if opts.ContentType != "" {
+		input.ContentType = aws.String(opts.ContentType)
+	}
+	if opts.ContentEncoding != "" {
+		input.ContentEncoding = aws.String(opts.ContentEncoding)
+	}
+	s.extras.apply(input)
+
+	_, err := s.uploader.UploadWithContext(ctx, input)
+	return err
+}
+
+// putObject uploads body to bucket/key via a single s3.PutObject call,
+// skipping the transfer manager's multipart machinery. This avoids
+// multipart overhead on small images and works against S3-compatible
+// endpoints that don't fully support multipart uploads.
+func (s *s3Storage) putObject(ctx context.Context, bucket, key string, body io.Reader, opts PutOptions) error {
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return err
+	}
+
+	input := &s3.PutObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+		Body:   bytes.NewReader(data),
+	}
+	if opts.ContentType != "" {
+		input.ContentType = aws.String(opts.ContentType)
+	}
+	if opts.ContentEncoding != "" {
+		input.ContentEncoding = aws.String(opts.ContentEncoding)
+	}
+	s.extras.applyToPutObject(input)
+
+	_, err = s.client.PutObjectWithContext(ctx, input)
+	return err
+}
+
+// GetMetadata returns bucket/key's user-defined metadata (the x-amz-meta-*
+// headers), keyed by lowercase name, satisfying the MetadataGetter
+// interface so the transform pipeline can be driven by it.
+func (s *s3Storage) GetMetadata(ctx context.Context, bucket, key string) (map[string]string, error) {
+	out, err := s.client.HeadObjectWithContext(ctx, &s3.HeadObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	metadata := make(map[string]string, len(out.Metadata))
+	for k, v := range out.Metadata {
+		if v != nil {
+			metadata[strings.ToLower(k)] = *v
+		}
+	}
+	return metadata, nil
+}
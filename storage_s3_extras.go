@@ -0,0 +1,77 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3manager"
+)
+
+// allowedStorageClasses are the S3 storage classes accepted via
+// S3_STORAGE_CLASS.
+var allowedStorageClasses = map[string]bool{
+	s3.StorageClassStandard:           true,
+	s3.StorageClassStandardIa:         true,
+	s3.StorageClassOnezoneIa:          true,
+	s3.StorageClassIntelligentTiering: true,
+	s3.StorageClassGlacierIr:          true,
+	s3.StorageClassGlacier:            true,
+	s3.StorageClassDeepArchive:        true,
+}
+
+// s3UploadExtras holds the destination StorageClass and server-side
+// encryption settings read from the environment, shared by every code path
+// that calls s3manager.Upload directly.
+type s3UploadExtras struct {
+	storageClass string
+	sse          string
+	kmsKeyID     string
+}
+
+// s3UploadExtrasFromEnv reads S3_STORAGE_CLASS, S3_SSE and S3_SSE_KMS_KEY_ID,
+// validating the storage class against allowedStorageClasses before any
+// upload is attempted.
+func s3UploadExtrasFromEnv() (s3UploadExtras, error) {
+	extras := s3UploadExtras{
+		storageClass: os.Getenv("S3_STORAGE_CLASS"),
+		sse:          os.Getenv("S3_SSE"),
+		kmsKeyID:     os.Getenv("S3_SSE_KMS_KEY_ID"),
+	}
+	if extras.storageClass != "" && !allowedStorageClasses[extras.storageClass] {
+		return s3UploadExtras{}, fmt.Errorf("unsupported S3_STORAGE_CLASS %q", extras.storageClass)
+	}
+	if extras.sse != "" && extras.sse != s3.ServerSideEncryptionAes256 && extras.sse != s3.ServerSideEncryptionAwsKms {
+		return s3UploadExtras{}, fmt.Errorf("unsupported S3_SSE %q", extras.sse)
+	}
+	return extras, nil
+}
+
+// apply sets the StorageClass and server-side encryption fields on input
+// according to e.
+func (e s3UploadExtras) apply(input *s3manager.UploadInput) {
+	if e.storageClass != "" {
+		input.StorageClass = aws.String(e.storageClass)
+	}
+	if e.sse != "" {
+		input.ServerSideEncryption = aws.String(e.sse)
+		if e.sse == s3.ServerSideEncryptionAwsKms && e.kmsKeyID != "" {
+			input.SSEKMSKeyId = aws.String(e.kmsKeyID)
+		}
+	}
+}
+
+// applyToPutObject sets the same fields as apply, on the single-shot
+// s3.PutObject path used when multipart uploads are disabled.
+func (e s3UploadExtras) applyToPutObject(input *s3.PutObjectInput) {
+	if e.storageClass != "" {
+		input.StorageClass = aws.String(e.storageClass)
+	}
+	if e.sse != "" {
+		input.ServerSideEncryption = aws.String(e.sse)
+		if e.sse == s3.ServerSideEncryptionAwsKms && e.kmsKeyID != "" {
+			input.SSEKMSKeyId = aws.String(e.kmsKeyID)
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3manager"
+)
+
+func TestS3UploadExtrasFromEnvRejectsUnsupportedStorageClass(t *testing.T) {
+	t.Setenv("S3_STORAGE_CLASS", "COLD_STORAGE")
+
+	if _, err := s3UploadExtrasFromEnv(); err == nil {
+		t.Fatalf("expected an error for an unsupported S3_STORAGE_CLASS")
+	}
+}
+
+func TestS3UploadExtrasFromEnvRejectsUnsupportedSSE(t *testing.T) {
+	t.Setenv("S3_SSE", "aws:des")
+
+	if _, err := s3UploadExtrasFromEnv(); err == nil {
+		t.Fatalf("expected an error for an unsupported S3_SSE")
+	}
+}
+
+func TestS3UploadExtrasApplySetsStorageClassAndSSE(t *testing.T) {
+	t.Setenv("S3_STORAGE_CLASS", s3.StorageClassGlacierIr)
+	t.Setenv("S3_SSE", s3.ServerSideEncryptionAwsKms)
+	t.Setenv("S3_SSE_KMS_KEY_ID", "arn:aws:kms:us-east-1:1234:key/abcd")
+
+	extras, err := s3UploadExtrasFromEnv()
+	if err != nil {
+		t.Fatalf("s3UploadExtrasFromEnv returned error: %v", err)
+	}
+
+	input := &s3manager.UploadInput{}
+	extras.apply(input)
+
+	if input.StorageClass == nil || *input.StorageClass != s3.StorageClassGlacierIr {
+		t.Fatalf("expected StorageClass %q, got %v", s3.StorageClassGlacierIr, input.StorageClass)
+	}
+	if input.ServerSideEncryption == nil || *input.ServerSideEncryption != s3.ServerSideEncryptionAwsKms {
+		t.Fatalf("expected ServerSideEncryption %q, got %v", s3.ServerSideEncryptionAwsKms, input.ServerSideEncryption)
+	}
+	if input.SSEKMSKeyId == nil || *input.SSEKMSKeyId != "arn:aws:kms:us-east-1:1234:key/abcd" {
+		t.Fatalf("expected SSEKMSKeyId to be set, got %v", input.SSEKMSKeyId)
+	}
+}
+
+func TestS3UploadExtrasApplyOmitsKMSKeyIDForAES256(t *testing.T) {
+	t.Setenv("S3_SSE", s3.ServerSideEncryptionAes256)
+	t.Setenv("S3_SSE_KMS_KEY_ID", "arn:aws:kms:us-east-1:1234:key/abcd")
+
+	extras, err := s3UploadExtrasFromEnv()
+	if err != nil {
+		t.Fatalf("s3UploadExtrasFromEnv returned error: %v", err)
+	}
+
+	input := &s3.PutObjectInput{}
+	extras.applyToPutObject(input)
+
+	if input.ServerSideEncryption == nil || *input.ServerSideEncryption != s3.ServerSideEncryptionAes256 {
+		t.Fatalf("expected ServerSideEncryption %q, got %v", s3.ServerSideEncryptionAes256, input.ServerSideEncryption)
+	}
+	if input.SSEKMSKeyId != nil {
+		t.Fatalf("expected SSEKMSKeyId to stay unset for AES256, got %v", *input.SSEKMSKeyId)
+	}
+}
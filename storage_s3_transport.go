@@ -0,0 +1,62 @@
+package main
+
+import (
+	"os"
+	"strconv"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3/s3manager"
+)
+
+// s3SessionConfig builds the *aws.Config used for the session, reflecting
+// S3_ENDPOINT and S3_FORCE_PATH_STYLE so the function can target
+// S3-compatible stores (MinIO, Ceph, ...) instead of AWS.
+func s3SessionConfig() *aws.Config {
+	cfg := aws.NewConfig()
+	if endpoint := os.Getenv("S3_ENDPOINT"); endpoint != "" {
+		cfg = cfg.WithEndpoint(endpoint)
+	}
+	if forcePathStyle, err := strconv.ParseBool(os.Getenv("S3_FORCE_PATH_STYLE")); err == nil {
+		cfg = cfg.WithS3ForcePathStyle(forcePathStyle)
+	}
+	return cfg
+}
+
+// configureUploader applies S3_PART_SIZE and S3_CONCURRENCY to an
+// s3manager.Uploader, leaving the SDK defaults in place when unset.
+func configureUploader(u *s3manager.Uploader) {
+	if partSize := envInt64("S3_PART_SIZE", 0); partSize > 0 {
+		u.PartSize = partSize
+	}
+	if concurrency := envInt("S3_CONCURRENCY", 0); concurrency > 0 {
+		u.Concurrency = concurrency
+	}
+}
+
+// envInt64 reads an int64 environment variable, returning fallback when it
+// is unset or cannot be parsed.
+func envInt64(key string, fallback int64) int64 {
+	raw := os.Getenv(key)
+	if raw == "" {
+		return fallback
+	}
+	n, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil {
+		return fallback
+	}
+	return n
+}
+
+// envBool reads a boolean environment variable, returning fallback when it
+// is unset or cannot be parsed.
+func envBool(key string, fallback bool) bool {
+	raw := os.Getenv(key)
+	if raw == "" {
+		return fallback
+	}
+	b, err := strconv.ParseBool(raw)
+	if err != nil {
+		return fallback
+	}
+	return b
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go/service/s3/s3manager"
+)
+
+func TestEnvBool(t *testing.T) {
+	t.Setenv("S3_DISABLE_MULTIPART", "true")
+	if got := envBool("S3_DISABLE_MULTIPART", false); got != true {
+		t.Fatalf("expected true, got %v", got)
+	}
+
+	t.Setenv("S3_DISABLE_MULTIPART", "not-a-bool")
+	if got := envBool("S3_DISABLE_MULTIPART", false); got != false {
+		t.Fatalf("expected fallback false for an unparsable value, got %v", got)
+	}
+
+	if got := envBool("S3_UNSET_FLAG", true); got != true {
+		t.Fatalf("expected fallback true for an unset variable, got %v", got)
+	}
+}
+
+func TestEnvInt64(t *testing.T) {
+	t.Setenv("S3_PART_SIZE", "10485760")
+	if got := envInt64("S3_PART_SIZE", 0); got != 10485760 {
+		t.Fatalf("expected 10485760, got %d", got)
+	}
+
+	t.Setenv("S3_PART_SIZE", "not-a-number")
+	if got := envInt64("S3_PART_SIZE", 5); got != 5 {
+		t.Fatalf("expected fallback 5 for an unparsable value, got %d", got)
+	}
+
+	if got := envInt64("S3_UNSET_SIZE", 5); got != 5 {
+		t.Fatalf("expected fallback 5 for an unset variable, got %d", got)
+	}
+}
+
+func TestS3SessionConfigDefaultsToNoEndpointOverride(t *testing.T) {
+	cfg := s3SessionConfig()
+
+	if cfg.Endpoint != nil {
+		t.Fatalf("expected no endpoint override, got %v", *cfg.Endpoint)
+	}
+	if cfg.S3ForcePathStyle != nil {
+		t.Fatalf("expected no S3ForcePathStyle override, got %v", *cfg.S3ForcePathStyle)
+	}
+}
+
+func TestS3SessionConfigHonorsEndpointAndPathStyle(t *testing.T) {
+	t.Setenv("S3_ENDPOINT", "http://minio.local:9000")
+	t.Setenv("S3_FORCE_PATH_STYLE", "true")
+
+	cfg := s3SessionConfig()
+
+	if cfg.Endpoint == nil || *cfg.Endpoint != "http://minio.local:9000" {
+		t.Fatalf("expected endpoint http://minio.local:9000, got %v", cfg.Endpoint)
+	}
+	if cfg.S3ForcePathStyle == nil || *cfg.S3ForcePathStyle != true {
+		t.Fatalf("expected S3ForcePathStyle true, got %v", cfg.S3ForcePathStyle)
+	}
+}
+
+func TestConfigureUploaderAppliesPartSizeAndConcurrency(t *testing.T) {
+	t.Setenv("S3_PART_SIZE", "16777216")
+	t.Setenv("S3_CONCURRENCY", "3")
+
+	uploader := &s3manager.Uploader{}
+	configureUploader(uploader)
+
+	if uploader.PartSize != 16777216 {
+		t.Fatalf("expected PartSize 16777216, got %d", uploader.PartSize)
+	}
+	if uploader.Concurrency != 3 {
+		t.Fatalf("expected Concurrency 3, got %d", uploader.Concurrency)
+	}
+}
+
+func TestConfigureUploaderLeavesDefaultsWhenUnset(t *testing.T) {
+	uploader := &s3manager.Uploader{PartSize: 5 * 1024 * 1024, Concurrency: 5}
+	configureUploader(uploader)
+
+	if uploader.PartSize != 5*1024*1024 {
+		t.Fatalf("expected default PartSize to be left untouched, got %d", uploader.PartSize)
+	}
+	if uploader.Concurrency != 5 {
+		t.Fatalf("expected default Concurrency to be left untouched, got %d", uploader.Concurrency)
+	}
+}
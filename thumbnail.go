@@ -0,0 +1,161 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/disintegration/imaging"
+	"golang.org/x/sync/errgroup"
+)
+
+// maxConcurrentThumbnailUploads bounds how many thumbnail variants are
+// resized, encoded and uploaded at the same time, so a large
+// THUMBNAIL_SIZES list can't exhaust Lambda's network/CPU allowance.
+const maxConcurrentThumbnailUploads = 4
+
+// defaultThumbnailSizes is used when THUMBNAIL_SIZES is unset.
+var defaultThumbnailSizes = []int{1600, 800, 400, 150}
+
+// defaultJPEGQuality is used when JPEG_QUALITY is unset or invalid.
+const defaultJPEGQuality = 85
+
+// VariantResult reports the outcome of rendering and uploading a single
+// thumbnail width.
+type VariantResult struct {
+	Width int    `json:"width"`
+	Key   string `json:"key"`
+	Ok    bool   `json:"ok"`
+	Error string `json:"error,omitempty"`
+}
+
+// runThumbnailPipeline resizes img to every width in THUMBNAIL_SIZES, encodes
+// each as a gzipped JPEG and uploads the variants to putBucketName via dst,
+// concurrently, bounded by maxConcurrentThumbnailUploads. It aggregates a
+// VariantResult per width so callers can tell which sizes failed.
+func runThumbnailPipeline(ctx context.Context, dst Storage, img image.Image, putBucketName, itemName string) (Response, error) {
+	sizes, err := parseThumbnailSizes(os.Getenv("THUMBNAIL_SIZES"))
+	if err != nil {
+		return Response{
+			Message: fmt.Sprint("Failed! An Error Occurred."),
+			Ok:      false,
+		}, err
+	}
+
+	quality := envInt("JPEG_QUALITY", defaultJPEGQuality)
+
+	var (
+		g        errgroup.Group
+		sem      = make(chan struct{}, maxConcurrentThumbnailUploads)
+		mu       sync.Mutex
+		variants []VariantResult
+	)
+
+	for _, width := range sizes {
+		width := width
+		g.Go(func() error {
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			result := uploadThumbnailVariant(ctx, dst, img, putBucketName, itemName, width, quality)
+
+			mu.Lock()
+			variants = append(variants, result)
+			mu.Unlock()
+			return nil
+		})
+	}
+	// errgroup.Group is only used here for its WaitGroup-like Wait(); each
+	// goroutine records its own outcome in variants instead of returning an
+	// error, so one failed variant doesn't cancel the others.
+	_ = g.Wait()
+
+	ok := true
+	for _, v := range variants {
+		if !v.Ok {
+			ok = false
+			break
+		}
+	}
+
+	return Response{
+		Message:  fmt.Sprintf("Thumbnail pipeline finished for %s.", itemName),
+		Ok:       ok,
+		Variants: variants,
+	}, nil
+}
+
+// uploadThumbnailVariant resizes img to width, JPEG-encodes it at quality,
+// gzips the result and uploads it to dst as "thumb-{width}-{itemName}".
+func uploadThumbnailVariant(ctx context.Context, dst Storage, img image.Image, putBucketName, itemName string, width, quality int) VariantResult {
+	resized := imaging.Resize(img, width, 0, imaging.Lanczos)
+
+	jpegBuff := new(bytes.Buffer)
+	if err := jpeg.Encode(jpegBuff, resized, &jpeg.Options{Quality: quality}); err != nil {
+		return VariantResult{Width: width, Ok: false, Error: err.Error()}
+	}
+
+	gzBuff := new(bytes.Buffer)
+	gzWriter, err := gzip.NewWriterLevel(gzBuff, gzip.BestCompression)
+	if err != nil {
+		return VariantResult{Width: width, Ok: false, Error: err.Error()}
+	}
+	if _, err := gzWriter.Write(jpegBuff.Bytes()); err != nil {
+		return VariantResult{Width: width, Ok: false, Error: err.Error()}
+	}
+	if err := gzWriter.Close(); err != nil {
+		return VariantResult{Width: width, Ok: false, Error: err.Error()}
+	}
+
+	key := fmt.Sprintf("thumb-%d-%s", width, itemName)
+	err = dst.Put(ctx, putBucketName, key, bytes.NewReader(gzBuff.Bytes()), PutOptions{
+		ContentType:     "image/jpeg",
+		ContentEncoding: "gzip",
+	})
+	if err != nil {
+		return VariantResult{Width: width, Key: key, Ok: false, Error: err.Error()}
+	}
+
+	return VariantResult{Width: width, Key: key, Ok: true}
+}
+
+// parseThumbnailSizes parses a comma-separated THUMBNAIL_SIZES value (e.g.
+// "1600,800,400,150") into a slice of pixel widths, falling back to
+// defaultThumbnailSizes when raw is empty.
+func parseThumbnailSizes(raw string) ([]int, error) {
+	if raw == "" {
+		return defaultThumbnailSizes, nil
+	}
+
+	parts := strings.Split(raw, ",")
+	sizes := make([]int, 0, len(parts))
+	for _, p := range parts {
+		width, err := strconv.Atoi(strings.TrimSpace(p))
+		if err != nil {
+			return nil, fmt.Errorf("invalid width %q in THUMBNAIL_SIZES: %w", p, err)
+		}
+		sizes = append(sizes, width)
+	}
+	return sizes, nil
+}
+
+// envInt reads an integer environment variable, returning fallback when it is
+// unset or cannot be parsed.
+func envInt(key string, fallback int) int {
+	raw := os.Getenv(key)
+	if raw == "" {
+		return fallback
+	}
+	n, err := strconv.Atoi(raw)
+	if err != nil {
+		return fallback
+	}
+	return n
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"image"
+	"image/jpeg"
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+func TestParseThumbnailSizesDefault(t *testing.T) {
+	sizes, err := parseThumbnailSizes("")
+	if err != nil {
+		t.Fatalf("parseThumbnailSizes returned error: %v", err)
+	}
+	if !reflect.DeepEqual(sizes, defaultThumbnailSizes) {
+		t.Fatalf("expected default sizes %v, got %v", defaultThumbnailSizes, sizes)
+	}
+}
+
+func TestParseThumbnailSizesCustom(t *testing.T) {
+	sizes, err := parseThumbnailSizes(" 1024, 512 ,256")
+	if err != nil {
+		t.Fatalf("parseThumbnailSizes returned error: %v", err)
+	}
+	want := []int{1024, 512, 256}
+	if !reflect.DeepEqual(sizes, want) {
+		t.Fatalf("expected %v, got %v", want, sizes)
+	}
+}
+
+func TestParseThumbnailSizesInvalid(t *testing.T) {
+	if _, err := parseThumbnailSizes("800,wide,400"); err == nil {
+		t.Fatalf("expected an error for a non-numeric width")
+	}
+}
+
+func TestUploadThumbnailVariant(t *testing.T) {
+	dst := newFakeStorage()
+	img := image.NewRGBA(image.Rect(0, 0, 16, 16))
+
+	result := uploadThumbnailVariant(context.Background(), dst, img, "dst-bucket", "photo.png", 8, defaultJPEGQuality)
+
+	if !result.Ok {
+		t.Fatalf("expected a successful variant, got error %q", result.Error)
+	}
+	if result.Key != "thumb-8-photo.png" {
+		t.Fatalf("expected key thumb-8-photo.png, got %q", result.Key)
+	}
+
+	opts, ok := dst.puts[fakeStorageKey("dst-bucket", result.Key)]
+	if !ok {
+		t.Fatalf("expected %s to have been uploaded", result.Key)
+	}
+	if opts.ContentType != "image/jpeg" {
+		t.Fatalf("expected content type image/jpeg, got %q", opts.ContentType)
+	}
+	if opts.ContentEncoding != "gzip" {
+		t.Fatalf("expected content encoding gzip, got %q", opts.ContentEncoding)
+	}
+
+	gzData := dst.objects[fakeStorageKey("dst-bucket", result.Key)]
+	gzReader, err := gzip.NewReader(bytes.NewReader(gzData))
+	if err != nil {
+		t.Fatalf("uploaded bytes are not valid gzip: %v", err)
+	}
+	defer gzReader.Close()
+
+	decoded, err := jpeg.Decode(gzReader)
+	if err != nil {
+		t.Fatalf("gunzipped bytes are not valid JPEG: %v", err)
+	}
+	if got := decoded.Bounds().Dx(); got != 8 {
+		t.Fatalf("expected resized width 8, got %d", got)
+	}
+}
+
+func TestRunThumbnailPipelineUploadsEveryConfiguredSize(t *testing.T) {
+	t.Setenv("THUMBNAIL_SIZES", "8,4")
+
+	dst := newFakeStorage()
+	img := image.NewRGBA(image.Rect(0, 0, 16, 16))
+
+	resp, err := runThumbnailPipeline(context.Background(), dst, img, "dst-bucket", "photo.png")
+	if err != nil {
+		t.Fatalf("runThumbnailPipeline returned error: %v", err)
+	}
+	if !resp.Ok {
+		t.Fatalf("expected a successful response, got %+v", resp)
+	}
+	if len(resp.Variants) != 2 {
+		t.Fatalf("expected 2 variants, got %d", len(resp.Variants))
+	}
+
+	for _, width := range []int{8, 4} {
+		key := fakeStorageKey("dst-bucket", "thumb-"+strconv.Itoa(width)+"-photo.png")
+		if _, ok := dst.objects[key]; !ok {
+			t.Fatalf("expected %s to have been uploaded", key)
+		}
+	}
+}